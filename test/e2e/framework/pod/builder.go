@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	imageutils "k8s.io/kubernetes/test/utils/image"
+	psaapi "k8s.io/pod-security-admission/api"
+)
+
+// OS aliases v1.OSName for callers that don't otherwise need to import k8s.io/api/core/v1.
+type OS = v1.OSName
+
+// OS names accepted by PodBuilder.WithOS.
+const (
+	OSLinux   = v1.Linux
+	OSWindows = v1.Windows
+)
+
+// Level aliases psaapi.Level for callers that don't otherwise need to import
+// k8s.io/pod-security-admission/api.
+type Level = psaapi.Level
+
+// Pod security levels accepted by PodBuilder.WithPSALevel.
+const (
+	LevelPrivileged = psaapi.LevelPrivileged
+	LevelBaseline   = psaapi.LevelBaseline
+	LevelRestricted = psaapi.LevelRestricted
+)
+
+// PodBuilder builds up a *v1.Pod through a chainable, declarative API, reusing the OS- and
+// PSA-aware helpers in this package so that tests don't have to hand-roll security contexts and
+// image/command selection for every OS they run against. A nil or zero-value PodBuilder is not
+// usable; always start from NewPodBuilder.
+type PodBuilder struct {
+	pod         *v1.Pod
+	imageIDs    []imageutils.ImageID
+	commands    map[int]string
+	psaLevel    *Level
+	hostProcess bool
+}
+
+// NewPodBuilder starts building a pod named name in namespace ns, with RestartPolicyNever as is
+// conventional for e2e test pods.
+func NewPodBuilder(name, ns string) *PodBuilder {
+	return &PodBuilder{
+		pod: &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+			},
+		},
+	}
+}
+
+// WithContainer appends a container named name using the test image for imageID, resolved
+// against the pod's OS (see WithOS) at Build time, so this may be called before or after WithOS.
+func (b *PodBuilder) WithContainer(name string, imageID imageutils.ImageID) *PodBuilder {
+	b.pod.Spec.Containers = append(b.pod.Spec.Containers, v1.Container{Name: name})
+	b.imageIDs = append(b.imageIDs, imageID)
+	return b
+}
+
+// WithCommand sets the command of the most recently added container, translating it via
+// GenerateScriptCmdForOS against the pod's OS (see WithOS) at Build time, so this may be called
+// before or after WithOS.
+func (b *PodBuilder) WithCommand(command string) *PodBuilder {
+	idx := b.lastContainerIndex()
+	if b.commands == nil {
+		b.commands = map[int]string{}
+	}
+	b.commands[idx] = command
+	return b
+}
+
+// WithOS declares the pod's target OS, overriding the --node-os-distro default used elsewhere in
+// this package for image selection and PSA mixins.
+func (b *PodBuilder) WithOS(os OS) *PodBuilder {
+	b.pod.Spec.OS = &v1.PodOS{Name: os}
+	return b
+}
+
+// WithPSALevel requests that Build mix in and validate the minimum security context required to
+// pass the given pod security level, via MixinPodSecurity (or MixinRestrictedPodSecurityForOS for
+// LevelRestricted, so WithHostProcess is honored on Windows).
+func (b *PodBuilder) WithPSALevel(level Level) *PodBuilder {
+	b.psaLevel = &level
+	return b
+}
+
+// WithHostProcess allows (or, if false, forbids) Windows HostProcess containers when mixing in
+// LevelRestricted. It has no effect at other levels or on Linux pods.
+func (b *PodBuilder) WithHostProcess(hostProcess bool) *PodBuilder {
+	b.hostProcess = hostProcess
+	return b
+}
+
+// WithVolume appends a volume to the pod.
+func (b *PodBuilder) WithVolume(volume v1.Volume) *PodBuilder {
+	b.pod.Spec.Volumes = append(b.pod.Spec.Volumes, volume)
+	return b
+}
+
+// Build resolves the container images against the pod's OS, applies the requested PSA level, and
+// returns the finished pod. If the requested PSA level can't be satisfied without overwriting
+// non-conformant configuration the caller already set, Build fails the test.
+func (b *PodBuilder) Build() *v1.Pod {
+	for i, imageID := range b.imageIDs {
+		b.pod.Spec.Containers[i].Image = b.testImage(imageID)
+	}
+	for idx, command := range b.commands {
+		b.pod.Spec.Containers[idx].Command = GenerateScriptCmdForOS(osNameForPod(b.pod), command)
+	}
+
+	if b.psaLevel != nil {
+		var err error
+		if *b.psaLevel == psaapi.LevelRestricted {
+			err = MixinRestrictedPodSecurityForOS(b.pod, b.hostProcess)
+		} else {
+			err = MixinPodSecurity(b.pod, *b.psaLevel, psaapi.LatestVersion())
+		}
+		gomega.ExpectWithOffset(1, err).NotTo(gomega.HaveOccurred())
+	}
+
+	return b.pod
+}
+
+// testImage resolves imageID against the pod's declared (or defaulted) OS, auto-selecting
+// Agnhost on Windows the same way GetTestImage does for --node-os-distro.
+func (b *PodBuilder) testImage(imageID imageutils.ImageID) string {
+	if osNameForPod(b.pod) == v1.Windows {
+		return imageutils.GetE2EImage(imageutils.Agnhost)
+	}
+	return imageutils.GetE2EImage(imageID)
+}
+
+// lastContainerIndex returns the index of the most recently added container, failing the test
+// immediately if WithContainer hasn't been called yet.
+func (b *PodBuilder) lastContainerIndex() int {
+	gomega.ExpectWithOffset(2, b.pod.Spec.Containers).NotTo(gomega.BeEmpty(), "WithContainer must be called before referring to a container")
+	return len(b.pod.Spec.Containers) - 1
+}
@@ -19,6 +19,8 @@ package pod
 import (
 	"flag"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/onsi/gomega"
 
@@ -49,6 +51,89 @@ func GenerateScriptCmd(command string) []string {
 	return commands
 }
 
+// GenerateOSScriptCmd generates the command line to execute command, using --node-os-distro to
+// pick a Windows or Linux shell; GenerateScriptCmd hardcodes /bin/sh, which doesn't exist on
+// Windows nodes. See GenerateScriptCmdForOS to dispatch on a pod's declared OS instead of the flag.
+func GenerateOSScriptCmd(command string) []string {
+	if NodeOSDistroIs("windows") {
+		return generateWindowsScriptCmd(command)
+	}
+	return GenerateScriptCmd(command)
+}
+
+// GenerateScriptCmdForOS generates the command line to execute command for the given OS. Unlike
+// GenerateOSScriptCmd, which reads the --node-os-distro flag, this dispatches on a pod's declared
+// Spec.OS.Name, so tests that run both Linux and Windows pods in the same test can generate the
+// right command for each one without relying on whatever the default target node happens to be.
+func GenerateScriptCmdForOS(os v1.OSName, command string) []string {
+	if os == v1.Windows {
+		return generateWindowsScriptCmd(command)
+	}
+	return GenerateScriptCmd(command)
+}
+
+// generateWindowsScriptCmd returns a PowerShell invocation of command, translating it first if it
+// matches one of the busybox idioms in busyboxToPowerShell so that a test written against the
+// canonical Linux command gets equivalent behavior on Windows. Commands that don't match any
+// known idiom fall back to running verbatim through cmd.exe, same as a real busybox shell would
+// for an unrecognized builtin.
+func generateWindowsScriptCmd(command string) []string {
+	if translated, ok := translateBusyboxCommand(command); ok {
+		return []string{"powershell.exe", "-Command", translated}
+	}
+	return []string{"cmd", "/S", "/C", command}
+}
+
+// busyboxToPowerShell translates a handful of common busybox idioms used throughout the e2e
+// suite into their PowerShell equivalent. It is not a general shell translator: anything not
+// matching one of these patterns (including "trap ... TERM", which has no PowerShell equivalent
+// that actually reacts to container termination the way a real SIGTERM trap does) is left for the
+// caller to run via cmd.exe.
+var busyboxToPowerShell = []struct {
+	busybox    *regexp.Regexp
+	powerShell func(match []string) string
+}{
+	{
+		// sleep N
+		busybox: regexp.MustCompile(`^sleep (\d+)$`),
+		powerShell: func(m []string) string {
+			return fmt.Sprintf("Start-Sleep -Seconds %s", m[1])
+		},
+	},
+	{
+		// echo TEXT > FILE
+		busybox: regexp.MustCompile(`^echo (.*) > (\S+)$`),
+		powerShell: func(m []string) string {
+			return fmt.Sprintf("Set-Content -Path %s -Value '%s'", m[2], escapePowerShellSingleQuoted(m[1]))
+		},
+	},
+	{
+		// cat FILE
+		busybox: regexp.MustCompile(`^cat (\S+)$`),
+		powerShell: func(m []string) string {
+			return fmt.Sprintf("Get-Content %s", m[1])
+		},
+	},
+}
+
+// escapePowerShellSingleQuoted escapes s for safe interpolation into a PowerShell single-quoted
+// string, by doubling any embedded single quotes -- the same rule PowerShell itself uses to
+// escape one, e.g. 'it''s done'.
+func escapePowerShellSingleQuoted(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+// translateBusyboxCommand translates command into its PowerShell equivalent if it matches one of
+// the idioms in busyboxToPowerShell, reporting whether a match was found.
+func translateBusyboxCommand(command string) (string, bool) {
+	for _, idiom := range busyboxToPowerShell {
+		if m := idiom.busybox.FindStringSubmatch(command); m != nil {
+			return idiom.powerShell(m), true
+		}
+	}
+	return "", false
+}
+
 // GetDefaultTestImage returns the default test image based on OS.
 // If the node OS is windows, currently we return Agnhost image for Windows node
 // due to the issue of #https://github.com/kubernetes-sigs/windows-testing/pull/35.
@@ -85,12 +170,27 @@ func GetTestImageID(id imageutils.ImageID) imageutils.ImageID {
 	return id
 }
 
-// GeneratePodSecurityContext generates the corresponding pod security context with the given inputs
-// If the Node OS is windows, currently we will ignore the inputs and return nil.
-// TODO: Will modify it after windows has its own security context
+// osNameForPod returns the OS that generators and mixins should assume for pod, preferring the
+// explicit pod.Spec.OS.Name when set and falling back to the --node-os-distro flag used by the
+// rest of this file when it is not.
+func osNameForPod(pod *v1.Pod) v1.OSName {
+	if pod != nil && pod.Spec.OS != nil && pod.Spec.OS.Name != "" {
+		return pod.Spec.OS.Name
+	}
+	if NodeOSDistroIs("windows") {
+		return v1.Windows
+	}
+	return v1.Linux
+}
+
+// GeneratePodSecurityContext generates the corresponding pod security context with the given inputs.
+// If the node OS is windows, fsGroup and seLinuxOptions don't apply; a default Windows pod
+// security context is returned instead (see GenerateWindowsPodSecurityContext). Callers that have
+// a pod to dispatch on, rather than relying on --node-os-distro, should use
+// GeneratePodSecurityContextForOS instead.
 func GeneratePodSecurityContext(fsGroup *int64, seLinuxOptions *v1.SELinuxOptions) *v1.PodSecurityContext {
 	if NodeOSDistroIs("windows") {
-		return nil
+		return GenerateWindowsPodSecurityContext("", false, nil)
 	}
 	return &v1.PodSecurityContext{
 		FSGroup:        fsGroup,
@@ -98,20 +198,76 @@ func GeneratePodSecurityContext(fsGroup *int64, seLinuxOptions *v1.SELinuxOption
 	}
 }
 
-// GenerateContainerSecurityContext generates the corresponding container security context with the given inputs
-// If the Node OS is windows, currently we will ignore the inputs and return nil.
-// TODO: Will modify it after windows has its own security context
+// GeneratePodSecurityContextForOS is GeneratePodSecurityContext, but dispatches on pod.Spec.OS.Name
+// (falling back to --node-os-distro) instead of --node-os-distro alone.
+func GeneratePodSecurityContextForOS(pod *v1.Pod, fsGroup *int64, seLinuxOptions *v1.SELinuxOptions) *v1.PodSecurityContext {
+	if osNameForPod(pod) == v1.Windows {
+		return GenerateWindowsPodSecurityContext("", false, nil)
+	}
+	return &v1.PodSecurityContext{
+		FSGroup:        fsGroup,
+		SELinuxOptions: seLinuxOptions,
+	}
+}
+
+// GenerateWindowsPodSecurityContext generates a pod security context for a Windows pod, populating
+// WindowsOptions with the given RunAsUserName, HostProcess setting, and (optional) GMSA credential
+// spec name.
+func GenerateWindowsPodSecurityContext(runAsUserName string, hostProcess bool, gmsaCredentialSpecName *string) *v1.PodSecurityContext {
+	windowsOptions := &v1.WindowsSecurityContextOptions{
+		HostProcess:            &hostProcess,
+		GMSACredentialSpecName: gmsaCredentialSpecName,
+	}
+	if runAsUserName != "" {
+		windowsOptions.RunAsUserName = &runAsUserName
+	}
+	return &v1.PodSecurityContext{
+		WindowsOptions: windowsOptions,
+	}
+}
+
+// GenerateContainerSecurityContext generates the corresponding container security context with the given inputs.
+// If the node OS is windows, privileged doesn't apply; a default Windows container security
+// context is returned instead (see GenerateWindowsContainerSecurityContext). Callers that have a
+// pod to dispatch on, rather than relying on --node-os-distro, should use
+// GenerateContainerSecurityContextForOS instead.
 func GenerateContainerSecurityContext(privileged bool) *v1.SecurityContext {
 	if NodeOSDistroIs("windows") {
-		return nil
+		return GenerateWindowsContainerSecurityContext("", false)
+	}
+	return &v1.SecurityContext{
+		Privileged: &privileged,
+	}
+}
+
+// GenerateContainerSecurityContextForOS is GenerateContainerSecurityContext, but dispatches on
+// pod.Spec.OS.Name (falling back to --node-os-distro) instead of --node-os-distro alone.
+func GenerateContainerSecurityContextForOS(pod *v1.Pod, privileged bool) *v1.SecurityContext {
+	if osNameForPod(pod) == v1.Windows {
+		return GenerateWindowsContainerSecurityContext("", false)
 	}
 	return &v1.SecurityContext{
 		Privileged: &privileged,
 	}
 }
 
+// GenerateWindowsContainerSecurityContext generates a container security context for a Windows
+// container, populating WindowsOptions with the given RunAsUserName and HostProcess setting.
+func GenerateWindowsContainerSecurityContext(runAsUserName string, hostProcess bool) *v1.SecurityContext {
+	windowsOptions := &v1.WindowsSecurityContextOptions{
+		HostProcess: &hostProcess,
+	}
+	if runAsUserName != "" {
+		windowsOptions.RunAsUserName = &runAsUserName
+	}
+	return &v1.SecurityContext{
+		WindowsOptions: windowsOptions,
+	}
+}
+
 // GetLinuxLabel returns the default SELinuxLabel based on OS.
-// If the node OS is windows, it will return nil
+// If the node OS is windows, it will return nil; see GetWindowsLabel. Callers that have a pod to
+// dispatch on, rather than relying on --node-os-distro, should use GetLinuxLabelForOS instead.
 func GetLinuxLabel() *v1.SELinuxOptions {
 	if NodeOSDistroIs("windows") {
 		return nil
@@ -120,6 +276,24 @@ func GetLinuxLabel() *v1.SELinuxOptions {
 		Level: "s0:c0,c1"}
 }
 
+// GetLinuxLabelForOS is GetLinuxLabel, but dispatches on pod.Spec.OS.Name (falling back to
+// --node-os-distro) instead of --node-os-distro alone.
+func GetLinuxLabelForOS(pod *v1.Pod) *v1.SELinuxOptions {
+	if osNameForPod(pod) == v1.Windows {
+		return nil
+	}
+	return &v1.SELinuxOptions{
+		Level: "s0:c0,c1"}
+}
+
+// GetWindowsLabel returns the default WindowsSecurityContextOptions. There is no Windows
+// equivalent of an SELinux label to default to, so this currently returns nil; it exists so
+// callers that branch on OS can call a single "get me the OS label" helper rather than
+// special-casing Windows.
+func GetWindowsLabel() *v1.WindowsSecurityContextOptions {
+	return nil
+}
+
 // DefaultNonRootUser is the default user ID used for running restricted (non-root) containers.
 const DefaultNonRootUser = 1000
 
@@ -142,6 +316,9 @@ func GetRestrictedContainerSecurityContext() *v1.SecurityContext {
 	}
 }
 
+// psaEvaluator is shared by every mixin in this file. DefaultChecks() doesn't vary by version or
+// level -- EvaluatePod takes the LevelVersion to check against as an argument -- so one Evaluator
+// covers all of them.
 var psaEvaluator, _ = psapolicy.NewEvaluator(psapolicy.DefaultChecks())
 
 // MustMixinRestrictedPodSecurity makes the given pod compliant with the restricted pod security level.
@@ -152,11 +329,96 @@ func MustMixinRestrictedPodSecurity(pod *v1.Pod) *v1.Pod {
 	return pod
 }
 
+// MixinRestrictedPodSecurityForOS makes the given pod compliant with the restricted pod security
+// level, branching on pod.Spec.OS.Name (falling back to --node-os-distro) to apply the
+// appropriate Linux or Windows defaults. Windows pods have no equivalent of
+// AllowPrivilegeEscalation or Capabilities, so the restricted checks that apply to them are
+// RunAsNonRoot and the absence of HostProcess; HostProcess is stripped unless allowHostProcess
+// is true, since HostProcess containers cannot pass the restricted profile. Like
+// MixinRestrictedPodSecurity, this covers Spec.EphemeralContainers as well as Spec.Containers and
+// Spec.InitContainers, since an ephemeral container is just as capable of bypassing admission.
+// If doing so would overwrite existing non-conformant configuration, an error is returned.
+func MixinRestrictedPodSecurityForOS(pod *v1.Pod, allowHostProcess bool) error {
+	if osNameForPod(pod) != v1.Windows {
+		return MixinRestrictedPodSecurity(pod)
+	}
+
+	if pod.Spec.SecurityContext == nil {
+		pod.Spec.SecurityContext = &v1.PodSecurityContext{}
+	}
+	if pod.Spec.SecurityContext.RunAsNonRoot == nil {
+		pod.Spec.SecurityContext.RunAsNonRoot = pointer.Bool(true)
+	}
+	if pod.Spec.SecurityContext.WindowsOptions == nil {
+		pod.Spec.SecurityContext.WindowsOptions = &v1.WindowsSecurityContextOptions{}
+	}
+	if pod.Spec.SecurityContext.WindowsOptions.HostProcess == nil {
+		pod.Spec.SecurityContext.WindowsOptions.HostProcess = pointer.Bool(allowHostProcess)
+	}
+
+	for i := range pod.Spec.Containers {
+		mixinRestrictedWindowsContainerSecurityContext(&pod.Spec.Containers[i], allowHostProcess)
+	}
+	for i := range pod.Spec.InitContainers {
+		mixinRestrictedWindowsContainerSecurityContext(&pod.Spec.InitContainers[i], allowHostProcess)
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		mixinRestrictedWindowsContainerSecurityContext((*v1.Container)(&pod.Spec.EphemeralContainers[i].EphemeralContainerCommon), allowHostProcess)
+	}
+
+	restricted := psaapi.LevelVersion{
+		Level:   psaapi.LevelRestricted,
+		Version: psaapi.LatestVersion(),
+	}
+	if agg := psapolicy.AggregateCheckResults(psaEvaluator.EvaluatePod(restricted, &pod.ObjectMeta, &pod.Spec)); !agg.Allowed {
+		return fmt.Errorf("failed to make pod %s restricted: %s", pod.Name, agg.ForbiddenDetail())
+	}
+
+	return nil
+}
+
+// mixinRestrictedWindowsContainerSecurityContext adds the Windows-appropriate container security
+// context options to be compliant with the restricted pod security level. Non-conformance
+// checking is handled by the caller.
+func mixinRestrictedWindowsContainerSecurityContext(container *v1.Container, allowHostProcess bool) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	if container.SecurityContext.WindowsOptions == nil {
+		container.SecurityContext.WindowsOptions = &v1.WindowsSecurityContextOptions{}
+	}
+	if container.SecurityContext.WindowsOptions.HostProcess == nil {
+		container.SecurityContext.WindowsOptions.HostProcess = pointer.Bool(allowHostProcess)
+	}
+}
+
 // MixinRestrictedPodSecurity makes the given pod compliant with the restricted pod security level.
+// This covers Spec.Containers, Spec.InitContainers, and Spec.EphemeralContainers: ephemeral
+// containers can bypass admission just as easily as any other container if they're left out
+// (CVE-2023-2727/2728), so they're mixed in and evaluated the same way the rest of the pod is.
 // If doing so would overwrite existing non-conformant configuration, an error is returned.
 // Note that this sets a default RunAsUser. See GetRestrictedPodSecurityContext.
-// TODO(#105919): Handle PodOS for windows pods.
+// For Windows pods, use MixinRestrictedPodSecurityForOS instead.
 func MixinRestrictedPodSecurity(pod *v1.Pod) error {
+	mixinRestrictedPodSecurityContext(pod)
+
+	// Validate the resulting pod against the restricted profile.
+	restricted := psaapi.LevelVersion{
+		Level:   psaapi.LevelRestricted,
+		Version: psaapi.LatestVersion(),
+	}
+	if agg := psapolicy.AggregateCheckResults(psaEvaluator.EvaluatePod(restricted, &pod.ObjectMeta, &pod.Spec)); !agg.Allowed {
+		return fmt.Errorf("failed to make pod %s restricted: %s", pod.Name, agg.ForbiddenDetail())
+	}
+
+	return nil
+}
+
+// mixinRestrictedPodSecurityContext adds the pod- and container-level security context options
+// required to be compliant with the restricted pod security level, without validating the
+// result; see MixinRestrictedPodSecurity and MixinPodSecurity, which both apply this and then
+// validate against the level/version they target.
+func mixinRestrictedPodSecurityContext(pod *v1.Pod) {
 	if pod.Spec.SecurityContext == nil {
 		pod.Spec.SecurityContext = GetRestrictedPodSecurityContext()
 	} else {
@@ -176,19 +438,57 @@ func MixinRestrictedPodSecurity(pod *v1.Pod) error {
 	for i := range pod.Spec.InitContainers {
 		mixinRestrictedContainerSecurityContext(&pod.Spec.InitContainers[i])
 	}
+	for i := range pod.Spec.EphemeralContainers {
+		mixinRestrictedContainerSecurityContext((*v1.Container)(&pod.Spec.EphemeralContainers[i].EphemeralContainerCommon))
+	}
+}
 
-	// Validate the resulting pod against the restricted profile.
+// MixinRestrictedEphemeralContainer makes ec compliant with the restricted pod security level and
+// validates it on its own. It's meant for tests that attach a debug container to an already
+// running pod via the ephemeral containers subresource, where there's no full pod spec being
+// resubmitted for MixinRestrictedPodSecurity to mix into and evaluate: this lets them enforce the
+// restricted profile at attach time and fail early instead of discovering the rejection from the
+// API server after the fact.
+func MixinRestrictedEphemeralContainer(ec *v1.EphemeralContainer) error {
+	container := (*v1.Container)(&ec.EphemeralContainerCommon)
+	mixinRestrictedContainerSecurityContext(container)
+	mixinRestrictedContainerPodLevelDefaults(container)
+
+	// EvaluatePod takes a whole pod spec, so wrap the container in a throwaway one. It has no
+	// pod-level SecurityContext, so the container carries every restricted setting itself.
+	synthetic := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{*container}}}
 	restricted := psaapi.LevelVersion{
 		Level:   psaapi.LevelRestricted,
 		Version: psaapi.LatestVersion(),
 	}
-	if agg := psapolicy.AggregateCheckResults(psaEvaluator.EvaluatePod(restricted, &pod.ObjectMeta, &pod.Spec)); !agg.Allowed {
-		return fmt.Errorf("failed to make pod %s restricted: %s", pod.Name, agg.ForbiddenDetail())
+	if agg := psapolicy.AggregateCheckResults(psaEvaluator.EvaluatePod(restricted, &synthetic.ObjectMeta, &synthetic.Spec)); !agg.Allowed {
+		return fmt.Errorf("failed to make ephemeral container %s restricted: %s", ec.Name, agg.ForbiddenDetail())
 	}
 
 	return nil
 }
 
+// mixinRestrictedContainerPodLevelDefaults sets the container-level equivalents of the pod-level
+// defaults mixinRestrictedPodSecurityContext applies (RunAsNonRoot, RunAsUser, SeccompProfile).
+// RunAsNonRoot and a non-Unconfined SeccompProfile are required by the restricted profile and are
+// normally supplied at the pod level; callers like MixinRestrictedEphemeralContainer that mix in
+// and evaluate a single container with no pod-level SecurityContext to inherit from need them set
+// here instead.
+func mixinRestrictedContainerPodLevelDefaults(container *v1.Container) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	if container.SecurityContext.RunAsNonRoot == nil {
+		container.SecurityContext.RunAsNonRoot = pointer.Bool(true)
+	}
+	if container.SecurityContext.RunAsUser == nil {
+		container.SecurityContext.RunAsUser = pointer.Int64(DefaultNonRootUser)
+	}
+	if container.SecurityContext.SeccompProfile == nil {
+		container.SecurityContext.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}
+	}
+}
+
 // mixinRestrictedContainerSecurityContext adds the required container security context options to
 // be compliant with the restricted pod security level. Non-conformance checking is handled by the
 // caller.
@@ -207,3 +507,129 @@ func mixinRestrictedContainerSecurityContext(container *v1.Container) {
 		}
 	}
 }
+
+// MixinPodSecurity mixes in the minimum pod and container security context defaults required for
+// pod to pass the given PSA level and version, then validates the result and returns the
+// aggregated forbidden reason on failure. Unlike MixinRestrictedPodSecurity, which always applies
+// the strictest and latest profile, this lets a test target a specific LevelVersion (for example
+// LevelVersion{Level: LevelBaseline, Version: MajorMinorVersion(1, 29)}) to exercise behavior that
+// only applies at that level, such as RunAsNonRoot and an explicitly set RuntimeDefault or
+// Localhost seccomp profile being required at restricted but not at baseline.
+//
+// mixinBaselinePodSecurityContext only covers a subset of the baseline checks (see its doc
+// comment); a pod that fails baseline for a reason outside that subset still returns an error here
+// rather than being silently corrected.
+func MixinPodSecurity(pod *v1.Pod, level psaapi.Level, version psaapi.Version) error {
+	switch level {
+	case psaapi.LevelPrivileged:
+		// The privileged profile has no requirements; nothing to mix in.
+	case psaapi.LevelBaseline:
+		mixinBaselinePodSecurityContext(pod)
+	case psaapi.LevelRestricted:
+		mixinRestrictedPodSecurityContext(pod)
+	default:
+		return fmt.Errorf("unknown pod security level %q", level)
+	}
+
+	levelVersion := psaapi.LevelVersion{Level: level, Version: version}
+	if agg := psapolicy.AggregateCheckResults(psaEvaluator.EvaluatePod(levelVersion, &pod.ObjectMeta, &pod.Spec)); !agg.Allowed {
+		return fmt.Errorf("failed to make pod %s comply with %s/%s: %s", pod.Name, level, version, agg.ForbiddenDetail())
+	}
+
+	return nil
+}
+
+// MixinBaselinePodSecurity makes the given pod compliant with the baseline pod security level at
+// the latest known version.
+func MixinBaselinePodSecurity(pod *v1.Pod) error {
+	return MixinPodSecurity(pod, psaapi.LevelBaseline, psaapi.LatestVersion())
+}
+
+// MixinPrivilegedPodSecurity makes the given pod compliant with the privileged pod security
+// level. This is a no-op: the privileged profile imposes no restrictions. It exists so callers
+// that pick a level dynamically (e.g. from a table of test cases) can call MixinPodSecurity, or
+// this shorthand, without special-casing "privileged means do nothing".
+func MixinPrivilegedPodSecurity(pod *v1.Pod) error {
+	return MixinPodSecurity(pod, psaapi.LevelPrivileged, psaapi.LatestVersion())
+}
+
+// mixinBaselinePodSecurityContext adds a subset of the pod- and container-level security context
+// options required to be compliant with the baseline pod security level, without validating the
+// result. It only clears host namespaces and privileged containers; it does NOT address the rest
+// of the baseline checks (hostPath volumes, host ports, non-default sysctls, a non-default
+// /proc mount type, or an Unconfined seccomp profile), so a pod that relies on one of those being
+// auto-corrected will still fail validation in MixinPodSecurity. Tests exercising those need to
+// set the relevant fields themselves before calling MixinBaselinePodSecurity.
+func mixinBaselinePodSecurityContext(pod *v1.Pod) {
+	pod.Spec.HostNetwork = false
+	pod.Spec.HostPID = false
+	pod.Spec.HostIPC = false
+	for i := range pod.Spec.Containers {
+		mixinBaselineContainerSecurityContext(&pod.Spec.Containers[i])
+	}
+	for i := range pod.Spec.InitContainers {
+		mixinBaselineContainerSecurityContext(&pod.Spec.InitContainers[i])
+	}
+}
+
+// mixinBaselineContainerSecurityContext clears the container security context options that the
+// baseline pod security level forbids outright, namely running privileged.
+func mixinBaselineContainerSecurityContext(container *v1.Container) {
+	if container.SecurityContext == nil {
+		return
+	}
+	if container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
+		container.SecurityContext.Privileged = pointer.Bool(false)
+	}
+}
+
+// EffectivePodSecurityContext returns the pod-level security context that applies to pod,
+// defaulting to an empty (non-nil) one so callers don't need a nil check.
+func EffectivePodSecurityContext(pod *v1.Pod) *v1.PodSecurityContext {
+	if pod.Spec.SecurityContext != nil {
+		return pod.Spec.SecurityContext
+	}
+	return &v1.PodSecurityContext{}
+}
+
+// EffectiveContainerSecurityContext returns the security context that will actually apply to
+// container, merging pod.Spec.SecurityContext down into container.SecurityContext: for
+// SELinuxOptions, RunAsUser, RunAsGroup, RunAsNonRoot, SeccompProfile and WindowsOptions.HostProcess,
+// an unset container field inherits the pod's value, and a set container field always wins. The
+// other WindowsOptions fields (RunAsUserName and the GMSA credential spec) are not inherited from
+// the pod. Fields that only exist at the pod level (e.g. SupplementalGroups) aren't part of
+// the result, since they apply to the pod as a whole rather than being merged per-container.
+func EffectiveContainerSecurityContext(pod *v1.Pod, container *v1.Container) *v1.SecurityContext {
+	podSC := EffectivePodSecurityContext(pod)
+
+	var effective v1.SecurityContext
+	if container.SecurityContext != nil {
+		effective = *container.SecurityContext.DeepCopy()
+	}
+
+	if effective.SELinuxOptions == nil {
+		effective.SELinuxOptions = podSC.SELinuxOptions
+	}
+	if effective.RunAsUser == nil {
+		effective.RunAsUser = podSC.RunAsUser
+	}
+	if effective.RunAsGroup == nil {
+		effective.RunAsGroup = podSC.RunAsGroup
+	}
+	if effective.RunAsNonRoot == nil {
+		effective.RunAsNonRoot = podSC.RunAsNonRoot
+	}
+	if effective.SeccompProfile == nil {
+		effective.SeccompProfile = podSC.SeccompProfile
+	}
+	if podSC.WindowsOptions != nil && podSC.WindowsOptions.HostProcess != nil {
+		if effective.WindowsOptions == nil {
+			effective.WindowsOptions = &v1.WindowsSecurityContextOptions{}
+		}
+		if effective.WindowsOptions.HostProcess == nil {
+			effective.WindowsOptions.HostProcess = podSC.WindowsOptions.HostProcess
+		}
+	}
+
+	return &effective
+}
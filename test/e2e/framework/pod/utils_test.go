@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestEffectiveContainerSecurityContext(t *testing.T) {
+	podLevel := &v1.SELinuxOptions{Level: "s0:c0,c1"}
+	containerLevel := &v1.SELinuxOptions{Level: "s0:c2,c3"}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		container *v1.Container
+		want      *v1.SecurityContext
+	}{
+		{
+			name:      "container inherits unset fields from pod",
+			pod:       &v1.Pod{Spec: v1.PodSpec{SecurityContext: &v1.PodSecurityContext{SELinuxOptions: podLevel, RunAsUser: pointer.Int64(1000)}}},
+			container: &v1.Container{},
+			want:      &v1.SecurityContext{SELinuxOptions: podLevel, RunAsUser: pointer.Int64(1000)},
+		},
+		{
+			name:      "set container fields override the pod",
+			pod:       &v1.Pod{Spec: v1.PodSpec{SecurityContext: &v1.PodSecurityContext{SELinuxOptions: podLevel, RunAsUser: pointer.Int64(1000)}}},
+			container: &v1.Container{SecurityContext: &v1.SecurityContext{SELinuxOptions: containerLevel}},
+			want:      &v1.SecurityContext{SELinuxOptions: containerLevel, RunAsUser: pointer.Int64(1000)},
+		},
+		{
+			name:      "container-only fields like Capabilities pass through unchanged",
+			pod:       &v1.Pod{},
+			container: &v1.Container{SecurityContext: &v1.SecurityContext{Capabilities: &v1.Capabilities{Drop: []v1.Capability{"ALL"}}}},
+			want:      &v1.SecurityContext{Capabilities: &v1.Capabilities{Drop: []v1.Capability{"ALL"}}},
+		},
+		{
+			name: "unset container HostProcess inherits the pod's WindowsOptions.HostProcess",
+			pod: &v1.Pod{Spec: v1.PodSpec{SecurityContext: &v1.PodSecurityContext{
+				WindowsOptions: &v1.WindowsSecurityContextOptions{HostProcess: pointer.Bool(true)},
+			}}},
+			container: &v1.Container{},
+			want:      &v1.SecurityContext{WindowsOptions: &v1.WindowsSecurityContextOptions{HostProcess: pointer.Bool(true)}},
+		},
+		{
+			name:      "nil pod and container security contexts produce an empty result",
+			pod:       &v1.Pod{},
+			container: &v1.Container{},
+			want:      &v1.SecurityContext{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EffectiveContainerSecurityContext(tc.pod, tc.container)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("EffectiveContainerSecurityContext() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEffectivePodSecurityContext(t *testing.T) {
+	if got := EffectivePodSecurityContext(&v1.Pod{}); got == nil {
+		t.Fatal("EffectivePodSecurityContext() returned nil for a pod with no security context")
+	}
+
+	want := &v1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)}
+	pod := &v1.Pod{Spec: v1.PodSpec{SecurityContext: want}}
+	if got := EffectivePodSecurityContext(pod); !reflect.DeepEqual(got, want) {
+		t.Errorf("EffectivePodSecurityContext() = %#v, want %#v", got, want)
+	}
+}
+
+func TestTranslateBusyboxCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "sleep",
+			command: "sleep 3600",
+			want:    "Start-Sleep -Seconds 3600",
+			wantOK:  true,
+		},
+		{
+			name:    "echo redirected to a file",
+			command: "echo hello > /tmp/foo",
+			want:    "Set-Content -Path /tmp/foo -Value 'hello'",
+			wantOK:  true,
+		},
+		{
+			name:    "echo with an embedded single quote is escaped, not broken",
+			command: "echo it's done > /tmp/foo",
+			want:    "Set-Content -Path /tmp/foo -Value 'it''s done'",
+			wantOK:  true,
+		},
+		{
+			name:    "cat",
+			command: "cat /tmp/foo",
+			want:    "Get-Content /tmp/foo",
+			wantOK:  true,
+		},
+		{
+			name:    "append redirection is not a supported idiom",
+			command: "echo hello >> /tmp/foo",
+			wantOK:  false,
+		},
+		{
+			name:    "trap is not a supported idiom",
+			command: "trap 'echo bye' TERM",
+			wantOK:  false,
+		},
+		{
+			name:    "unrecognized command",
+			command: "some-random-binary --flag",
+			wantOK:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := translateBusyboxCommand(tc.command)
+			if ok != tc.wantOK {
+				t.Fatalf("translateBusyboxCommand(%q) ok = %v, want %v", tc.command, ok, tc.wantOK)
+			}
+			if ok && got != tc.want {
+				t.Errorf("translateBusyboxCommand(%q) = %q, want %q", tc.command, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWindowsScriptCmd(t *testing.T) {
+	if got, want := generateWindowsScriptCmd("sleep 10"), []string{"powershell.exe", "-Command", "Start-Sleep -Seconds 10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("generateWindowsScriptCmd(sleep) = %v, want %v", got, want)
+	}
+	if got, want := generateWindowsScriptCmd("some-random-binary --flag"), []string{"cmd", "/S", "/C", "some-random-binary --flag"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("generateWindowsScriptCmd(unrecognized) = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateScriptCmdForOS(t *testing.T) {
+	if got, want := GenerateScriptCmdForOS(v1.Linux, "sleep 10"), GenerateScriptCmd("sleep 10"); !reflect.DeepEqual(got, want) {
+		t.Errorf("GenerateScriptCmdForOS(Linux) = %v, want %v", got, want)
+	}
+	if got, want := GenerateScriptCmdForOS(v1.Windows, "sleep 10"), generateWindowsScriptCmd("sleep 10"); !reflect.DeepEqual(got, want) {
+		t.Errorf("GenerateScriptCmdForOS(Windows) = %v, want %v", got, want)
+	}
+}